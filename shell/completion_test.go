@@ -0,0 +1,52 @@
+package shell
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestGenerateCompletionScript(t *testing.T) {
+	cases := []struct {
+		shell    string
+		contains string
+	}{
+		{"bash", "complete -F _mybin_completion mybin"},
+		{"zsh", "compdef _mybin_completion mybin"},
+		{"fish", "complete -c mybin -f -a '(__mybin_completion)'"},
+		{"powershell", "Register-ArgumentCompleter -Native -CommandName mybin"},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.shell, func(t *testing.T) {
+			a := &ShellCli[struct{}]{ProjectName: "mybin"}
+
+			var buf bytes.Buffer
+			if err := a.GenerateCompletionScript(tc.shell, &buf); err != nil {
+				t.Fatalf("GenerateCompletionScript(%q) returned error: %v", tc.shell, err)
+			}
+
+			if !strings.Contains(buf.String(), tc.contains) {
+				t.Fatalf("GenerateCompletionScript(%q) output missing %q, got:\n%s", tc.shell, tc.contains, buf.String())
+			}
+		})
+	}
+}
+
+func TestGenerateCompletionScriptRequiresProjectName(t *testing.T) {
+	a := &ShellCli[struct{}]{}
+
+	var buf bytes.Buffer
+	if err := a.GenerateCompletionScript("bash", &buf); err == nil {
+		t.Fatalf("GenerateCompletionScript should error when ProjectName is unset")
+	}
+}
+
+func TestGenerateCompletionScriptUnknownShell(t *testing.T) {
+	a := &ShellCli[struct{}]{ProjectName: "mybin"}
+
+	var buf bytes.Buffer
+	if err := a.GenerateCompletionScript("powerfish", &buf); err == nil {
+		t.Fatalf("GenerateCompletionScript should error for an unknown shell")
+	}
+}
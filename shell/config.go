@@ -0,0 +1,116 @@
+package shell
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// ShellConfig is the hot-reloadable configuration WatchConfig parses: command
+// aliases, per-command default arguments, and the prompt template.
+type ShellConfig struct {
+	// Aliases maps an alias name to the real command name it should dispatch to.
+	Aliases map[string]string `toml:"aliases" yaml:"aliases"`
+
+	// DefaultArgs maps a command name to argument values applied when the user
+	// doesn't supply that argument explicitly.
+	DefaultArgs map[string]map[string]string `toml:"default_args" yaml:"default_args"`
+
+	// PromptTemplate is made available to the caller's Prompter function via
+	// ShellCli[T].Config(); it is not interpreted by this package.
+	PromptTemplate string `toml:"prompt_template" yaml:"prompt_template"`
+}
+
+// ConfigProvider loads a ShellConfig from a path. ShellCli[T] defaults to
+// fileConfigProvider, which supports TOML and YAML based on file extension.
+type ConfigProvider interface {
+	Load(path string) (*ShellConfig, error)
+}
+
+// fileConfigProvider is the default ConfigProvider, reading TOML (.toml) or
+// YAML (.yaml/.yml) files from disk.
+type fileConfigProvider struct{}
+
+func (fileConfigProvider) Load(path string) (*ShellConfig, error) {
+	cfg := &ShellConfig{}
+
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".toml":
+		if _, err := toml.DecodeFile(path, cfg); err != nil {
+			return nil, fmt.Errorf("error decoding toml config: %s", err)
+		}
+
+	case ".yaml", ".yml":
+		data, err := os.ReadFile(path)
+
+		if err != nil {
+			return nil, fmt.Errorf("error reading config: %s", err)
+		}
+
+		if err := yaml.Unmarshal(data, cfg); err != nil {
+			return nil, fmt.Errorf("error decoding yaml config: %s", err)
+		}
+
+	default:
+		return nil, fmt.Errorf("unsupported config format: %s", ext)
+	}
+
+	return cfg, nil
+}
+
+// WatchConfig loads path with a.ConfigProvider (defaulting to fileConfigProvider)
+// and registers a SIGHUP reload hook so it's re-parsed and atomically swapped in
+// on every SIGHUP, without restarting the shell. It also performs the initial load.
+func (a *ShellCli[T]) WatchConfig(path string) error {
+	if a.ConfigProvider == nil {
+		a.ConfigProvider = fileConfigProvider{}
+	}
+
+	a.configPath = path
+
+	if err := a.Reload(); err != nil {
+		return err
+	}
+
+	OnReload(func() {
+		if err := a.Reload(); err != nil {
+			fmt.Println("Error reloading config: ", err)
+		}
+	})
+
+	return nil
+}
+
+// Reload re-parses the config registered via WatchConfig and atomically swaps it
+// in. It's exposed directly so tests, and platforms without SIGHUP, can trigger a
+// reload manually instead of relying on the signal.
+func (a *ShellCli[T]) Reload() error {
+	if a.configPath == "" {
+		return fmt.Errorf("no config path set; call WatchConfig first")
+	}
+
+	cfg, err := a.ConfigProvider.Load(a.configPath)
+
+	if err != nil {
+		return err
+	}
+
+	a.configMu.Lock()
+	a.config = cfg
+	a.configMu.Unlock()
+
+	return nil
+}
+
+// Config returns the currently loaded ShellConfig, or nil if WatchConfig has
+// never been called. Safe to call concurrently with a reload.
+func (a *ShellCli[T]) Config() *ShellConfig {
+	a.configMu.RLock()
+	defer a.configMu.RUnlock()
+
+	return a.config
+}
@@ -0,0 +1,170 @@
+package shell
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/go-andiamo/splitter"
+)
+
+func TestFileConfigProviderLoadTOML(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.toml")
+	writeFile(t, path, `
+prompt_template = "{cwd}> "
+
+[aliases]
+d = "db"
+
+[default_args.deploy]
+env = "staging"
+`)
+
+	cfg, err := (fileConfigProvider{}).Load(path)
+	if err != nil {
+		t.Fatalf("Load returned error: %v", err)
+	}
+
+	if cfg.PromptTemplate != "{cwd}> " {
+		t.Fatalf("PromptTemplate = %q, want %q", cfg.PromptTemplate, "{cwd}> ")
+	}
+
+	if cfg.Aliases["d"] != "db" {
+		t.Fatalf("Aliases[d] = %q, want %q", cfg.Aliases["d"], "db")
+	}
+
+	if cfg.DefaultArgs["deploy"]["env"] != "staging" {
+		t.Fatalf("DefaultArgs[deploy][env] = %q, want %q", cfg.DefaultArgs["deploy"]["env"], "staging")
+	}
+}
+
+func TestFileConfigProviderLoadYAML(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	writeFile(t, path, `
+prompt_template: "{cwd}> "
+aliases:
+  d: db
+default_args:
+  deploy:
+    env: staging
+`)
+
+	cfg, err := (fileConfigProvider{}).Load(path)
+	if err != nil {
+		t.Fatalf("Load returned error: %v", err)
+	}
+
+	if cfg.PromptTemplate != "{cwd}> " {
+		t.Fatalf("PromptTemplate = %q, want %q", cfg.PromptTemplate, "{cwd}> ")
+	}
+
+	if cfg.Aliases["d"] != "db" {
+		t.Fatalf("Aliases[d] = %q, want %q", cfg.Aliases["d"], "db")
+	}
+
+	if cfg.DefaultArgs["deploy"]["env"] != "staging" {
+		t.Fatalf("DefaultArgs[deploy][env] = %q, want %q", cfg.DefaultArgs["deploy"]["env"], "staging")
+	}
+}
+
+func TestFileConfigProviderLoadUnsupportedExtension(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.ini")
+	writeFile(t, path, "")
+
+	if _, err := (fileConfigProvider{}).Load(path); err == nil {
+		t.Fatalf("Load should error on an unsupported config extension")
+	}
+}
+
+func TestShellCliReloadAndConfig(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.toml")
+	writeFile(t, path, `
+[aliases]
+d = "db"
+`)
+
+	a := &ShellCli[struct{}]{}
+
+	if err := a.WatchConfig(path); err != nil {
+		t.Fatalf("WatchConfig returned error: %v", err)
+	}
+
+	if a.Config().Aliases["d"] != "db" {
+		t.Fatalf("Config().Aliases[d] = %q, want %q", a.Config().Aliases["d"], "db")
+	}
+
+	writeFile(t, path, `
+[aliases]
+d = "deploy"
+`)
+
+	if err := a.Reload(); err != nil {
+		t.Fatalf("Reload returned error: %v", err)
+	}
+
+	if a.Config().Aliases["d"] != "deploy" {
+		t.Fatalf("Config().Aliases[d] after Reload = %q, want %q", a.Config().Aliases["d"], "deploy")
+	}
+}
+
+func TestShellCliReloadWithoutWatchConfig(t *testing.T) {
+	a := &ShellCli[struct{}]{}
+
+	if err := a.Reload(); err == nil {
+		t.Fatalf("Reload should error when WatchConfig has never been called")
+	}
+}
+
+func TestCreateArgMapFromArgsDefaultArgsPrecedence(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.toml")
+	writeFile(t, path, `
+[default_args.deploy]
+env = "staging"
+region = "us-east"
+`)
+
+	a := newTestShellCli()
+	a.Output = NewOutput("text")
+
+	argSplitter, err := splitter.NewSplitter('=',
+		splitter.Parenthesis, splitter.SquareBrackets, splitter.CurlyBrackets,
+		splitter.DoubleQuotesBackSlashEscaped, splitter.SingleQuotesBackSlashEscaped)
+	if err != nil {
+		t.Fatalf("splitter.NewSplitter returned error: %v", err)
+	}
+	argSplitter.AddDefaultOptions(splitter.IgnoreEmptyFirst, splitter.IgnoreEmptyLast, splitter.TrimSpaces, splitter.UnescapeQuotes)
+	a.ArgSplitter = argSplitter
+
+	if err := a.WatchConfig(path); err != nil {
+		t.Fatalf("WatchConfig returned error: %v", err)
+	}
+
+	cmd := &Command[struct{}]{
+		Name: "deploy",
+		Args: [][3]string{
+			{"env", "environment", ""},
+			{"region", "region", ""},
+		},
+	}
+
+	argMap, err := a.CreateArgMapFromArgs(cmd, []string{"env=prod"})
+	if err != nil {
+		t.Fatalf("CreateArgMapFromArgs returned error: %v", err)
+	}
+
+	if argMap["env"] != "prod" {
+		t.Fatalf(`argMap["env"] = %q, want explicit "prod" to win over the default`, argMap["env"])
+	}
+
+	if argMap["region"] != "us-east" {
+		t.Fatalf(`argMap["region"] = %q, want the configured default "us-east"`, argMap["region"])
+	}
+}
+
+func writeFile(t *testing.T, path, contents string) {
+	t.Helper()
+
+	if err := os.WriteFile(path, []byte(contents), 0o644); err != nil {
+		t.Fatalf("failed writing test config %q: %v", path, err)
+	}
+}
@@ -0,0 +1,28 @@
+package shell
+
+// EditMode selects the keybinding set used by ShellCli[T].Run()'s prompt.
+type EditMode int
+
+const (
+	// EditModeDefault binds only lineEditor's base keys (arrows, Home/End, Delete,
+	// Backspace, Tab completion, Ctrl-R search), with no Emacs or Vi extras.
+	EditModeDefault EditMode = iota
+
+	// EditModeEmacs layers Ctrl-W/Ctrl-A/Ctrl-U/Ctrl-Y kill-ring bindings on top.
+	EditModeEmacs
+
+	// EditModeVi implements Vi-style insert/normal modes with iteration-prefixed
+	// motions (d3b, y4w, ...) and the numbered/lettered register set.
+	EditModeVi
+)
+
+func (m EditMode) String() string {
+	switch m {
+	case EditModeEmacs:
+		return "emacs"
+	case EditModeVi:
+		return "vi"
+	default:
+		return "default"
+	}
+}
@@ -0,0 +1,38 @@
+package shell
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestExtractFormatFlagDefault(t *testing.T) {
+	args, format := extractFormatFlag([]string{"a=1", "b=2"})
+
+	if format != "text" {
+		t.Fatalf("format = %q, want %q", format, "text")
+	}
+
+	if want := []string{"a=1", "b=2"}; !reflect.DeepEqual(args, want) {
+		t.Fatalf("args = %v, want %v", args, want)
+	}
+}
+
+func TestExtractFormatFlagSelectsAndRemovesFlag(t *testing.T) {
+	args, format := extractFormatFlag([]string{"a=1", "--format=json", "b=2"})
+
+	if format != "json" {
+		t.Fatalf("format = %q, want %q", format, "json")
+	}
+
+	if want := []string{"a=1", "b=2"}; !reflect.DeepEqual(args, want) {
+		t.Fatalf("args = %v, want %v", args, want)
+	}
+}
+
+func TestExtractFormatFlagLastWins(t *testing.T) {
+	_, format := extractFormatFlag([]string{"--format=yaml", "--format=table"})
+
+	if format != "table" {
+		t.Fatalf("format = %q, want %q", format, "table")
+	}
+}
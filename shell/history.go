@@ -0,0 +1,162 @@
+package shell
+
+import (
+	"database/sql"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	_ "modernc.org/sqlite"
+)
+
+// HistoryEntry is one executed command as recorded by ShellCli[T].Run().
+type HistoryEntry struct {
+	Command    string
+	Timestamp  int64 // unix epoch, seconds
+	Cwd        string
+	Failed     bool // true if Exec returned a non-nil error
+	DurationMs int64
+}
+
+// HistorySearchScope narrows a HistoryStore.Search call, matching the sub-modes
+// cycled through with Ctrl-R in the reverse-search prompt.
+type HistorySearchScope int
+
+const (
+	// HistoryScopeGlobal searches all recorded history.
+	HistoryScopeGlobal HistorySearchScope = iota
+
+	// HistoryScopeCwd restricts the search to commands run in the current working directory.
+	HistoryScopeCwd
+
+	// HistoryScopeToday restricts the search to commands run since midnight, local time.
+	HistoryScopeToday
+)
+
+func (s HistorySearchScope) String() string {
+	switch s {
+	case HistoryScopeCwd:
+		return "cwd"
+	case HistoryScopeToday:
+		return "today"
+	default:
+		return "global"
+	}
+}
+
+// NextScope cycles Global -> Cwd -> Today -> Global, the order the Ctrl-R hotkey steps through.
+func (s HistorySearchScope) NextScope() HistorySearchScope {
+	switch s {
+	case HistoryScopeGlobal:
+		return HistoryScopeCwd
+	case HistoryScopeCwd:
+		return HistoryScopeToday
+	default:
+		return HistoryScopeGlobal
+	}
+}
+
+// HistoryStore is the persistence backend for executed-command history. ShellCli[T]
+// ships a default sqliteHistoryStore, but users may plug in their own implementation
+// via ShellCli[T].HistoryStore.
+type HistoryStore interface {
+	// Record persists a single executed command.
+	Record(entry HistoryEntry) error
+
+	// Search returns matching entries, most recent first, optionally narrowed by scope.
+	// An empty query matches everything in scope.
+	Search(query string, scope HistorySearchScope) ([]HistoryEntry, error)
+
+	// Close releases any resources held by the store.
+	Close() error
+}
+
+// sqliteHistoryStore is the default HistoryStore, backed by a SQLite database file.
+type sqliteHistoryStore struct {
+	db *sql.DB
+}
+
+// NewSQLiteHistoryStore opens (creating if needed) a SQLite-backed HistoryStore at path.
+func NewSQLiteHistoryStore(path string) (*sqliteHistoryStore, error) {
+	db, err := sql.Open("sqlite", path)
+
+	if err != nil {
+		return nil, fmt.Errorf("error opening history database: %s", err)
+	}
+
+	_, err = db.Exec(`CREATE TABLE IF NOT EXISTS history (
+		command     TEXT NOT NULL,
+		ts          INTEGER NOT NULL,
+		cwd         TEXT NOT NULL,
+		failed      INTEGER NOT NULL,
+		duration_ms INTEGER NOT NULL
+	)`)
+
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("error creating history table: %s", err)
+	}
+
+	return &sqliteHistoryStore{db: db}, nil
+}
+
+func (h *sqliteHistoryStore) Record(entry HistoryEntry) error {
+	_, err := h.db.Exec(
+		`INSERT INTO history (command, ts, cwd, failed, duration_ms) VALUES (?, ?, ?, ?, ?)`,
+		entry.Command, entry.Timestamp, entry.Cwd, entry.Failed, entry.DurationMs,
+	)
+
+	return err
+}
+
+func (h *sqliteHistoryStore) Search(query string, scope HistorySearchScope) ([]HistoryEntry, error) {
+	clauses := []string{"command LIKE ?"}
+	params := []any{"%" + query + "%"}
+
+	switch scope {
+	case HistoryScopeCwd:
+		cwd, err := os.Getwd()
+		if err != nil {
+			return nil, err
+		}
+		clauses = append(clauses, "cwd = ?")
+		params = append(params, cwd)
+	case HistoryScopeToday:
+		clauses = append(clauses, "ts >= ?")
+		params = append(params, startOfToday().Unix())
+	}
+
+	rows, err := h.db.Query(
+		"SELECT command, ts, cwd, failed, duration_ms FROM history WHERE "+
+			strings.Join(clauses, " AND ")+" ORDER BY ts DESC LIMIT 200",
+		params...,
+	)
+
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var entries []HistoryEntry
+
+	for rows.Next() {
+		var e HistoryEntry
+		if err := rows.Scan(&e.Command, &e.Timestamp, &e.Cwd, &e.Failed, &e.DurationMs); err != nil {
+			return nil, err
+		}
+		entries = append(entries, e)
+	}
+
+	return entries, rows.Err()
+}
+
+func (h *sqliteHistoryStore) Close() error {
+	return h.db.Close()
+}
+
+func startOfToday() time.Time {
+	now := time.Now()
+	y, m, d := now.Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, now.Location())
+}
@@ -0,0 +1,98 @@
+package shell
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestSQLiteHistoryStoreRecordAndSearch(t *testing.T) {
+	store, err := NewSQLiteHistoryStore(filepath.Join(t.TempDir(), "history.db"))
+	if err != nil {
+		t.Fatalf("NewSQLiteHistoryStore returned error: %v", err)
+	}
+	defer store.Close()
+
+	cwd, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("os.Getwd returned error: %v", err)
+	}
+
+	now := time.Now()
+
+	entries := []HistoryEntry{
+		{Command: "help", Timestamp: startOfToday().Unix(), Cwd: cwd, Failed: false, DurationMs: 1},
+		{Command: "zzzmarkerone", Timestamp: now.Unix(), Cwd: cwd, Failed: false, DurationMs: 2},
+		{Command: "old command", Timestamp: startOfToday().Add(-time.Hour).Unix(), Cwd: "/somewhere/else", Failed: true, DurationMs: 3},
+	}
+
+	for _, e := range entries {
+		if err := store.Record(e); err != nil {
+			t.Fatalf("Record(%+v) returned error: %v", e, err)
+		}
+	}
+
+	results, err := store.Search("", HistoryScopeGlobal)
+	if err != nil {
+		t.Fatalf("Search(global) returned error: %v", err)
+	}
+
+	if len(results) != 3 {
+		t.Fatalf("Search(global) returned %d entries, want 3", len(results))
+	}
+
+	if results[0].Command != "zzzmarkerone" {
+		t.Fatalf("Search(global)[0].Command = %q, want most recent entry first", results[0].Command)
+	}
+
+	results, err = store.Search("marker", HistoryScopeGlobal)
+	if err != nil {
+		t.Fatalf("Search(marker) returned error: %v", err)
+	}
+
+	if len(results) != 1 || results[0].Command != "zzzmarkerone" {
+		t.Fatalf("Search(%q) = %+v, want a single zzzmarkerone match", "marker", results)
+	}
+
+	results, err = store.Search("", HistoryScopeCwd)
+	if err != nil {
+		t.Fatalf("Search(cwd) returned error: %v", err)
+	}
+
+	for _, r := range results {
+		if r.Cwd != cwd {
+			t.Fatalf("Search(cwd) returned an entry from %q, want only %q", r.Cwd, cwd)
+		}
+	}
+
+	if len(results) != 2 {
+		t.Fatalf("Search(cwd) returned %d entries, want 2", len(results))
+	}
+
+	results, err = store.Search("", HistoryScopeToday)
+	if err != nil {
+		t.Fatalf("Search(today) returned error: %v", err)
+	}
+
+	if len(results) != 2 {
+		t.Fatalf("Search(today) returned %d entries, want 2 (\"old command\" was recorded before today)", len(results))
+	}
+}
+
+func TestHistorySearchScopeNextScope(t *testing.T) {
+	cases := []struct {
+		from HistorySearchScope
+		want HistorySearchScope
+	}{
+		{HistoryScopeGlobal, HistoryScopeCwd},
+		{HistoryScopeCwd, HistoryScopeToday},
+		{HistoryScopeToday, HistoryScopeGlobal},
+	}
+
+	for _, tc := range cases {
+		if got := tc.from.NextScope(); got != tc.want {
+			t.Fatalf("%v.NextScope() = %v, want %v", tc.from, got, tc.want)
+		}
+	}
+}
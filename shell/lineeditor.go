@@ -0,0 +1,563 @@
+package shell
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"golang.org/x/term"
+)
+
+// lineEditor is a raw-mode line editor used in place of liner's own Prompt loop.
+// liner doesn't expose the raw keystrokes Emacs/Vi mode need (kill-ring cuts, vi
+// motions) or a hook for HistoryStore-backed Ctrl-R search, so we read runes off
+// the terminal ourselves, parsing the ANSI escape sequences (arrow keys,
+// Home/End/Delete) liner would otherwise handle and re-wiring Tab completion
+// through ShellCli.CompletionHandler so every existing Completer still drives
+// suggestions. EditMode only changes which *extra* keys are bound on top of this
+// (Ctrl-W/Ctrl-Y for Emacs, modal motions for Vi); history, completion, and
+// Ctrl-R work the same regardless of EditMode.
+type lineEditor[T any] struct {
+	shell    *ShellCli[T]
+	in       *bufio.Reader
+	fd       int
+	oldState *term.State
+
+	// vi-only state
+	viInsert bool // true while in insert mode; false while in normal mode
+
+	// history navigation (Up/Down), most recent last
+	history    []string
+	historyPos int    // index into history; == len(history) means "at the live buffer"
+	liveBuf    []rune // buffer saved when navigation starts, restored on Down past the end
+}
+
+func newLineEditor[T any](s *ShellCli[T]) *lineEditor[T] {
+	e := &lineEditor[T]{
+		shell:    s,
+		in:       bufio.NewReader(os.Stdin),
+		fd:       int(os.Stdin.Fd()),
+		viInsert: true,
+	}
+
+	if s.HistoryStore != nil {
+		if entries, err := s.HistoryStore.Search("", HistoryScopeGlobal); err == nil {
+			for i := len(entries) - 1; i >= 0; i-- {
+				e.history = append(e.history, entries[i].Command)
+			}
+		}
+	}
+
+	return e
+}
+
+// appendHistory makes cmd immediately recallable via Up, without waiting for the
+// next lineEditor to be constructed and re-query the HistoryStore. Called from
+// recordHistory right after a command finishes executing.
+func (e *lineEditor[T]) appendHistory(cmd string) {
+	if strings.TrimSpace(cmd) == "" {
+		return
+	}
+
+	e.history = append(e.history, cmd)
+}
+
+func (e *lineEditor[T]) Close() {
+	if e.oldState != nil {
+		term.Restore(e.fd, e.oldState)
+		e.oldState = nil
+	}
+}
+
+// Prompt reads a single line of input, applying arrow-key/Home/End/Delete
+// navigation and Tab completion universally, plus the configured EditMode's
+// extra bindings.
+func (e *lineEditor[T]) Prompt(prompt string) (string, error) {
+	oldState, err := term.MakeRaw(e.fd)
+
+	if err != nil {
+		// Not an interactive terminal (e.g. piped input); fall back to a plain read.
+		fmt.Print(prompt)
+		line, rerr := e.in.ReadString('\n')
+		return strings.TrimRight(line, "\r\n"), rerr
+	}
+
+	e.oldState = oldState
+	defer func() {
+		term.Restore(e.fd, e.oldState)
+		e.oldState = nil
+	}()
+
+	fmt.Print(prompt)
+
+	buf := []rune{}
+	pos := 0
+	e.viInsert = true
+	e.historyPos = len(e.history)
+	e.liveBuf = nil
+
+	redraw := func() {
+		fmt.Print("\r\x1b[K", prompt, string(buf))
+		if back := len(buf) - pos; back > 0 {
+			fmt.Printf("\x1b[%dD", back)
+		}
+	}
+
+	for {
+		r, _, err := e.in.ReadRune()
+
+		if err != nil {
+			return string(buf), err
+		}
+
+		switch {
+		case r == '\r' || r == '\n':
+			fmt.Print("\r\n")
+			return string(buf), nil
+
+		case r == 4 && len(buf) == 0: // Ctrl-D on empty line: signal EOF like liner did
+			fmt.Print("\r\n")
+			return "", io.EOF
+
+		case r == 9: // Tab: delegate to ShellCli.CompletionHandler
+			buf, pos = e.handleTabCompletion(buf)
+
+		case r == 18: // Ctrl-R: interactive reverse search against the HistoryStore
+			if match, ok := e.reverseSearch(); ok {
+				buf = []rune(match)
+				pos = len(buf)
+			}
+
+		case r == 27: // Esc, or the start of an ANSI escape sequence
+			if seq, ok := e.readEscapeSequence(); ok {
+				buf, pos = e.applyEscape(seq, buf, pos)
+			} else if e.shell.EditMode == EditModeVi {
+				e.viInsert = false
+				if pos > 0 {
+					pos--
+				}
+			}
+			// A lone Esc is a no-op in Emacs/Default mode.
+
+		case e.shell.EditMode == EditModeEmacs:
+			buf, pos = e.applyEmacs(r, buf, pos)
+
+		case e.shell.EditMode == EditModeVi:
+			buf, pos = e.applyVi(r, buf, pos)
+
+		case r == 127 || r == 8: // Backspace
+			if pos > 0 {
+				buf = append(buf[:pos-1], buf[pos:]...)
+				pos--
+			}
+
+		default:
+			buf, pos = insertRune(buf, pos, r)
+		}
+
+		redraw()
+	}
+}
+
+// escapeSeq identifies a parsed ANSI escape/CSI sequence.
+type escapeSeq int
+
+const (
+	escNone escapeSeq = iota
+	escUp
+	escDown
+	escLeft
+	escRight
+	escHome
+	escEnd
+	escDelete
+)
+
+// readEscapeSequence reads the runes following an Esc (27) that has already been
+// consumed, recognizing the CSI sequences arrow keys/Home/End/Delete send. If the
+// following rune isn't '[', it's unread so the next Prompt iteration sees it as a
+// plain keystroke, and (escNone, false) is returned (a bare Esc).
+func (e *lineEditor[T]) readEscapeSequence() (escapeSeq, bool) {
+	r1, _, err := e.in.ReadRune()
+
+	if err != nil {
+		return escNone, false
+	}
+
+	if r1 != '[' {
+		e.in.UnreadRune()
+		return escNone, false
+	}
+
+	r2, _, err := e.in.ReadRune()
+
+	if err != nil {
+		return escNone, false
+	}
+
+	switch r2 {
+	case 'A':
+		return escUp, true
+	case 'B':
+		return escDown, true
+	case 'C':
+		return escRight, true
+	case 'D':
+		return escLeft, true
+	case 'H':
+		return escHome, true
+	case 'F':
+		return escEnd, true
+	case '1', '3', '4':
+		r3, _, err := e.in.ReadRune()
+
+		if err != nil || r3 != '~' {
+			return escNone, false
+		}
+
+		switch r2 {
+		case '1':
+			return escHome, true
+		case '4':
+			return escEnd, true
+		case '3':
+			return escDelete, true
+		}
+	}
+
+	return escNone, false
+}
+
+func (e *lineEditor[T]) applyEscape(seq escapeSeq, buf []rune, pos int) ([]rune, int) {
+	switch seq {
+	case escUp:
+		return e.historyPrev(buf)
+	case escDown:
+		return e.historyNext(buf)
+	case escLeft:
+		if pos > 0 {
+			pos--
+		}
+		return buf, pos
+	case escRight:
+		if pos < len(buf) {
+			pos++
+		}
+		return buf, pos
+	case escHome:
+		return buf, 0
+	case escEnd:
+		return buf, len(buf)
+	case escDelete:
+		if pos < len(buf) {
+			buf = append(buf[:pos], buf[pos+1:]...)
+		}
+		return buf, pos
+	default:
+		return buf, pos
+	}
+}
+
+// historyPrev recalls the previous (older) history entry, saving the in-progress
+// buffer the first time it's called so Down can restore it.
+func (e *lineEditor[T]) historyPrev(buf []rune) ([]rune, int) {
+	if e.historyPos == 0 {
+		return buf, len(buf)
+	}
+
+	if e.historyPos == len(e.history) {
+		e.liveBuf = append([]rune{}, buf...)
+	}
+
+	e.historyPos--
+	newBuf := []rune(e.history[e.historyPos])
+
+	return newBuf, len(newBuf)
+}
+
+// historyNext recalls the next (newer) history entry, or restores the in-progress
+// buffer once navigation runs past the newest entry.
+func (e *lineEditor[T]) historyNext(buf []rune) ([]rune, int) {
+	if e.historyPos >= len(e.history) {
+		return buf, len(buf)
+	}
+
+	e.historyPos++
+
+	if e.historyPos == len(e.history) {
+		return append([]rune{}, e.liveBuf...), len(e.liveBuf)
+	}
+
+	newBuf := []rune(e.history[e.historyPos])
+
+	return newBuf, len(newBuf)
+}
+
+// handleTabCompletion calls ShellCli.CompletionHandler with the buffer typed so
+// far. A single match replaces the line outright (mirroring liner's behavior);
+// multiple matches are printed below the prompt, like liner.TabPrints.
+func (e *lineEditor[T]) handleTabCompletion(buf []rune) ([]rune, int) {
+	completions := e.shell.CompletionHandler(string(buf))
+
+	switch len(completions) {
+	case 0:
+		return buf, len(buf)
+	case 1:
+		newBuf := []rune(completions[0])
+		return newBuf, len(newBuf)
+	default:
+		fmt.Print("\r\n", strings.Join(completions, "  "), "\r\n")
+		return buf, len(buf)
+	}
+}
+
+func insertRune(buf []rune, pos int, r rune) ([]rune, int) {
+	buf = append(buf[:pos], append([]rune{r}, buf[pos:]...)...)
+	return buf, pos + 1
+}
+
+// applyEmacs implements Ctrl-W (cut previous word), Ctrl-A (start of line),
+// Ctrl-U (cut whole line) and Ctrl-Y (yank) on top of plain insertion.
+func (e *lineEditor[T]) applyEmacs(r rune, buf []rune, pos int) ([]rune, int) {
+	regs := e.shell.Registers()
+
+	switch r {
+	case 23: // Ctrl-W
+		start := prevWordBoundary(buf, pos)
+		regs.Kill(string(buf[start:pos]), false)
+		buf = append(buf[:start], buf[pos:]...)
+		return buf, start
+
+	case 1: // Ctrl-A
+		return buf, 0
+
+	case 5: // Ctrl-E
+		return buf, len(buf)
+
+	case 21: // Ctrl-U
+		regs.Kill(string(buf), false)
+		return []rune{}, 0
+
+	case 25: // Ctrl-Y
+		yank := []rune(regs.Yank())
+		buf = append(buf[:pos], append(yank, buf[pos:]...)...)
+		return buf, pos + len(yank)
+
+	case 127, 8: // Backspace
+		if pos > 0 {
+			buf = append(buf[:pos-1], buf[pos:]...)
+			pos--
+		}
+		return buf, pos
+
+	default:
+		return insertRune(buf, pos, r)
+	}
+}
+
+func prevWordBoundary(buf []rune, pos int) int {
+	i := pos
+
+	for i > 0 && buf[i-1] == ' ' {
+		i--
+	}
+
+	for i > 0 && buf[i-1] != ' ' {
+		i--
+	}
+
+	return i
+}
+
+// applyVi implements a small subset of Vi: insert/normal mode toggling via Esc/i/a,
+// iteration-prefixed motions (d3b, y4w) and registers addressed via a leading
+// "<letter> prefix, standard vi order (e.g. "ayw yanks word into register a).
+func (e *lineEditor[T]) applyVi(r rune, buf []rune, pos int) ([]rune, int) {
+	if e.viInsert {
+		switch r {
+		case 127, 8:
+			if pos > 0 {
+				buf = append(buf[:pos-1], buf[pos:]...)
+				pos--
+			}
+			return buf, pos
+		default:
+			return insertRune(buf, pos, r)
+		}
+	}
+
+	// Normal mode
+	switch r {
+	case 'i':
+		e.viInsert = true
+		return buf, pos
+	case 'a':
+		e.viInsert = true
+		if pos < len(buf) {
+			pos++
+		}
+		return buf, pos
+	case 'h':
+		if pos > 0 {
+			pos--
+		}
+		return buf, pos
+	case 'l':
+		if pos < len(buf) {
+			pos++
+		}
+		return buf, pos
+	case '"': // register prefix: "<letter><op><motion>, e.g. "ayw
+		name, _, err := e.in.ReadRune()
+
+		if err != nil {
+			return buf, pos
+		}
+
+		op, _, err := e.in.ReadRune()
+
+		if err != nil || (op != 'd' && op != 'y') {
+			return buf, pos
+		}
+
+		return e.viOperator(op, string(name), buf, pos)
+	case 'd', 'y':
+		return e.viOperator(r, "\"", buf, pos)
+	default:
+		return buf, pos
+	}
+}
+
+// viOperator parses a trailing "<count><motion>" sequence (e.g. "d3b", "y4w") for
+// the delete/yank operator op, targeting register reg, reading further runes from
+// e.in. The register is selected beforehand via a leading "<letter> prefix (see
+// applyVi), matching standard vi order rather than the operator.
+func (e *lineEditor[T]) viOperator(op rune, reg string, buf []rune, pos int) ([]rune, int) {
+	count := ""
+
+	r, _, err := e.in.ReadRune()
+	if err != nil {
+		return buf, pos
+	}
+
+	for r >= '1' && r <= '9' || (count != "" && r == '0') {
+		count += string(r)
+		r, _, err = e.in.ReadRune()
+		if err != nil {
+			return buf, pos
+		}
+	}
+
+	n := 1
+	if count != "" {
+		if parsed, perr := strconv.Atoi(count); perr == nil {
+			n = parsed
+		}
+	}
+
+	start, end := pos, pos
+
+	switch r {
+	case 'w':
+		end = pos
+		for i := 0; i < n; i++ {
+			end = nextWordBoundary(buf, end)
+		}
+	case 'b':
+		start = pos
+		for i := 0; i < n; i++ {
+			start = prevWordBoundary(buf, start)
+		}
+	default:
+		return buf, pos
+	}
+
+	if start > end {
+		start, end = end, start
+	}
+
+	cut := string(buf[start:end])
+
+	if reg == "\"" {
+		// No explicit register named: goes through the unnamed register and
+		// shifts the numbered ring, same as real vim.
+		e.shell.Registers().Kill(cut, false)
+	} else {
+		e.shell.Registers().Set(reg, cut, false)
+	}
+
+	if op == 'd' {
+		buf = append(buf[:start], buf[end:]...)
+		return buf, start
+	}
+
+	// 'y' leaves the buffer untouched
+	return buf, pos
+}
+
+// reverseSearch implements the Ctrl-R prompt: it renders
+// "(reverse-search <scope>)`query`: match" inline, updating the match as the user
+// types, and cycles scope (global -> cwd -> today) each time Ctrl-R is pressed
+// again. Matches that failed are rendered in red. Enter accepts the current match,
+// Esc cancels.
+func (e *lineEditor[T]) reverseSearch() (string, bool) {
+	scope := HistoryScopeGlobal
+	query := ""
+
+	for {
+		results, _ := e.shell.HistoryStore.Search(query, scope)
+
+		var match string
+		var failed bool
+
+		if len(results) > 0 {
+			match = results[0].Command
+			failed = results[0].Failed
+		}
+
+		color := ""
+		if failed {
+			color = "\x1b[31m"
+		}
+
+		fmt.Printf("\r\x1b[K(reverse-search %s)`%s`: %s%s\x1b[0m", scope, query, color, match)
+
+		r, _, err := e.in.ReadRune()
+
+		if err != nil {
+			return "", false
+		}
+
+		switch r {
+		case 18: // Ctrl-R again: cycle scope
+			scope = scope.NextScope()
+		case 27: // Esc: cancel
+			return "", false
+		case '\r', '\n':
+			return match, true
+		case 127, 8:
+			if len(query) > 0 {
+				query = query[:len(query)-1]
+			}
+		default:
+			query += string(r)
+		}
+	}
+}
+
+func nextWordBoundary(buf []rune, pos int) int {
+	i := pos
+
+	for i < len(buf) && buf[i] != ' ' {
+		i++
+	}
+
+	for i < len(buf) && buf[i] == ' ' {
+		i++
+	}
+
+	return i
+}
@@ -0,0 +1,251 @@
+package shell
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"gopkg.in/yaml.v3"
+)
+
+// OutputSchema optionally describes the shape of a Command[T]'s output, so that
+// JSON/YAML emitters can produce stable, ordered keys instead of relying on
+// map iteration order or struct field order.
+type OutputSchema struct {
+	// Fields lists the field/column names, in display order.
+	Fields []string
+}
+
+// Output is how a Command[T] reports results, instead of calling fmt.Println
+// directly. The format it renders in (json/yaml/table/text) is selected per
+// invocation by the global --format flag parsed in Exec.
+type Output interface {
+	// Emit renders a single value.
+	Emit(v any)
+
+	// Table renders tabular data.
+	Table(headers []string, rows [][]string)
+
+	// Error renders a command failure.
+	Error(err error)
+}
+
+// NewOutput returns the Output implementation for the given format
+// (json/yaml/table/text); unrecognized formats fall back to "text".
+func NewOutput(format string) Output {
+	return &basicOutput{format: format}
+}
+
+// formatOf returns the format string o was constructed with, or "" if o isn't
+// a *basicOutput. Callers that need to branch on format themselves (e.g. to
+// decide whether to build a structured value at all) can use this instead of
+// duplicating NewOutput's format set.
+func formatOf(o Output) string {
+	bo, ok := o.(*basicOutput)
+	if !ok {
+		return ""
+	}
+
+	return bo.format
+}
+
+// basicOutput is the default Output implementation backing every format.
+type basicOutput struct {
+	format string
+
+	// schema, when set, orders the keys of map[string]any values emitted as
+	// JSON. It's populated from the invoked Command[T].Schema in Exec.
+	schema *OutputSchema
+}
+
+func (o *basicOutput) Emit(v any) {
+	switch o.format {
+	case "json":
+		data, err := json.MarshalIndent(o.ordered(v), "", "  ")
+
+		if err != nil {
+			fmt.Println("Error encoding json: ", err)
+			return
+		}
+
+		fmt.Println(string(data))
+
+	case "yaml":
+		data, err := yaml.Marshal(o.ordered(v))
+
+		if err != nil {
+			fmt.Println("Error encoding yaml: ", err)
+			return
+		}
+
+		fmt.Print(string(data))
+
+	default: // "text", "table"
+		fmt.Println(v)
+	}
+}
+
+// ordered wraps v in orderedFields when o.schema is set and v is a
+// map[string]any or []map[string]string, so JSON output honors the schema's
+// field order instead of Go's sorted-key default. Anything else passes
+// through unchanged.
+func (o *basicOutput) ordered(v any) any {
+	if o.schema == nil {
+		return v
+	}
+
+	switch m := v.(type) {
+	case map[string]any:
+		return orderedFields{fields: o.schema.Fields, values: m}
+	case []map[string]string:
+		out := make([]any, len(m))
+		for i, row := range m {
+			values := make(map[string]any, len(row))
+			for k, val := range row {
+				values[k] = val
+			}
+			out[i] = orderedFields{fields: o.schema.Fields, values: values}
+		}
+		return out
+	default:
+		return v
+	}
+}
+
+// orderedFields marshals values to JSON in the key order given by fields,
+// falling back to sorted order for any keys fields doesn't mention.
+type orderedFields struct {
+	fields []string
+	values map[string]any
+}
+
+// order returns of.values' keys in of.fields order, falling back to sorted
+// order for any keys of.fields doesn't mention. Shared by MarshalJSON and
+// MarshalYAML so both formats honor the same key order.
+func (of orderedFields) order() []string {
+	seen := make(map[string]bool, len(of.fields))
+	order := make([]string, 0, len(of.values))
+
+	for _, f := range of.fields {
+		if _, ok := of.values[f]; ok && !seen[f] {
+			order = append(order, f)
+			seen[f] = true
+		}
+	}
+
+	var rest []string
+	for k := range of.values {
+		if !seen[k] {
+			rest = append(rest, k)
+		}
+	}
+	sort.Strings(rest)
+
+	return append(order, rest...)
+}
+
+func (of orderedFields) MarshalJSON() ([]byte, error) {
+	order := of.order()
+
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+
+	for i, k := range order {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+
+		key, err := json.Marshal(k)
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(key)
+		buf.WriteByte(':')
+
+		val, err := json.Marshal(of.values[k])
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(val)
+	}
+
+	buf.WriteByte('}')
+
+	return buf.Bytes(), nil
+}
+
+// MarshalYAML builds a mapping node with its keys in of.order() order; yaml.v3
+// has no MapSlice-style ordered-map type, so we construct the node directly.
+func (of orderedFields) MarshalYAML() (any, error) {
+	node := &yaml.Node{Kind: yaml.MappingNode}
+
+	for _, k := range of.order() {
+		keyNode := &yaml.Node{}
+		if err := keyNode.Encode(k); err != nil {
+			return nil, err
+		}
+
+		valNode := &yaml.Node{}
+		if err := valNode.Encode(of.values[k]); err != nil {
+			return nil, err
+		}
+
+		node.Content = append(node.Content, keyNode, valNode)
+	}
+
+	return node, nil
+}
+
+func (o *basicOutput) Table(headers []string, rows [][]string) {
+	switch o.format {
+	case "json", "yaml":
+		o.Emit(tableRows(headers, rows))
+	default: // "text", "table"
+		renderTable(headers, rows)
+	}
+}
+
+func (o *basicOutput) Error(err error) {
+	switch o.format {
+	case "json", "yaml":
+		o.Emit(map[string]string{"error": err.Error()})
+	default:
+		fmt.Println("Error: ", err)
+	}
+}
+
+// tableRows turns headers/rows into a slice of maps, one per row, so JSON/YAML
+// output gets stable, named keys instead of bare arrays.
+func tableRows(headers []string, rows [][]string) []map[string]string {
+	out := make([]map[string]string, 0, len(rows))
+
+	for _, row := range rows {
+		m := make(map[string]string, len(headers))
+
+		for i, header := range headers {
+			if i < len(row) {
+				m[header] = row[i]
+			}
+		}
+
+		out = append(out, m)
+	}
+
+	return out
+}
+
+func renderTable(headers []string, rows [][]string) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+
+	fmt.Fprintln(w, strings.Join(headers, "\t"))
+
+	for _, row := range rows {
+		fmt.Fprintln(w, strings.Join(row, "\t"))
+	}
+
+	w.Flush()
+}
@@ -0,0 +1,89 @@
+package shell
+
+import (
+	"reflect"
+	"testing"
+)
+
+func newTestShellCli() *ShellCli[struct{}] {
+	root := &Command[struct{}]{
+		Name: "db",
+		Subcommands: map[string]*Command[struct{}]{
+			"migrate": {
+				Name: "migrate",
+				Subcommands: map[string]*Command[struct{}]{
+					"up": {Name: "up"},
+				},
+			},
+		},
+	}
+
+	return &ShellCli[struct{}]{
+		Commands: map[string]*Command[struct{}]{
+			"db": root,
+		},
+	}
+}
+
+func TestParseOutCommandDescendsSubcommands(t *testing.T) {
+	a := newTestShellCli()
+
+	cmd, rest, err := a.ParseOutCommand([]string{"db", "migrate", "up", "target=1"})
+
+	if err != nil {
+		t.Fatalf("ParseOutCommand returned error: %v", err)
+	}
+
+	if cmd == nil || cmd.Name != "up" {
+		t.Fatalf("ParseOutCommand resolved %+v, want the \"up\" subcommand", cmd)
+	}
+
+	if want := []string{"target=1"}; !reflect.DeepEqual(rest, want) {
+		t.Fatalf("ParseOutCommand rest = %v, want %v", rest, want)
+	}
+}
+
+func TestParseOutCommandStopsAtArgToken(t *testing.T) {
+	a := newTestShellCli()
+
+	cmd, rest, err := a.ParseOutCommand([]string{"db", "migrate=1"})
+
+	if err != nil {
+		t.Fatalf("ParseOutCommand returned error: %v", err)
+	}
+
+	if cmd == nil || cmd.Name != "db" {
+		t.Fatalf("ParseOutCommand resolved %+v, want the \"db\" command", cmd)
+	}
+
+	if want := []string{"migrate=1"}; !reflect.DeepEqual(rest, want) {
+		t.Fatalf("ParseOutCommand rest = %v, want %v", rest, want)
+	}
+}
+
+func TestParseOutCommandResolvesAlias(t *testing.T) {
+	a := newTestShellCli()
+	a.config = &ShellConfig{Aliases: map[string]string{"d": "db"}}
+
+	cmd, rest, err := a.ParseOutCommand([]string{"d", "migrate"})
+
+	if err != nil {
+		t.Fatalf("ParseOutCommand returned error: %v", err)
+	}
+
+	if cmd == nil || cmd.Name != "migrate" {
+		t.Fatalf("ParseOutCommand resolved %+v, want the \"migrate\" subcommand", cmd)
+	}
+
+	if len(rest) != 0 {
+		t.Fatalf("ParseOutCommand rest = %v, want empty", rest)
+	}
+}
+
+func TestParseOutCommandUnknownCommand(t *testing.T) {
+	a := newTestShellCli()
+
+	if _, _, err := a.ParseOutCommand([]string{"nope"}); err == nil {
+		t.Fatalf("ParseOutCommand should return an error for an unknown command")
+	}
+}
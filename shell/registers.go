@@ -0,0 +1,89 @@
+package shell
+
+import "sync"
+
+// Register holds a single chunk of killed/yanked text, keyed by register name.
+type Register struct {
+	Text string
+
+	// Linewise marks whether Text represents one or more whole lines (Vi "d3d")
+	// as opposed to a sub-line span (Vi "d3b"). Emacs never sets this.
+	Linewise bool
+}
+
+// RegisterSet is the kill-ring/register store shared by Emacs and Vi edit modes.
+//
+// Emacs mode yanks only from the unnamed "\"" register (its "kill-ring"), though its
+// cuts go through Kill and so also shift the numbered registers; Vi mode
+// additionally uses the ten numbered registers "0"-"9" and the 26 lettered registers
+// "a"-"z", with the convention that writing to an uppercase letter (e.g. "A") appends
+// to the lowercase register instead of replacing it.
+type RegisterSet struct {
+	mu        sync.Mutex
+	registers map[string]*Register
+}
+
+// NewRegisterSet returns an empty RegisterSet.
+func NewRegisterSet() *RegisterSet {
+	return &RegisterSet{registers: make(map[string]*Register)}
+}
+
+// Get returns the named register, or a zero Register if it has never been written to.
+func (r *RegisterSet) Get(name string) Register {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if reg, ok := r.registers[name]; ok {
+		return *reg
+	}
+
+	return Register{}
+}
+
+// Set writes text to the named register.
+//
+// If name is an uppercase letter, the text is appended to the corresponding
+// lowercase register instead of replacing it, per Vi append-on-uppercase semantics.
+func (r *RegisterSet) Set(name string, text string, linewise bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if len(name) == 1 && name[0] >= 'A' && name[0] <= 'Z' {
+		lower := string(name[0] + ('a' - 'A'))
+		existing := r.registers[lower]
+
+		if existing == nil {
+			r.registers[lower] = &Register{Text: text, Linewise: linewise}
+		} else {
+			existing.Text += text
+			existing.Linewise = existing.Linewise || linewise
+		}
+
+		return
+	}
+
+	r.registers[name] = &Register{Text: text, Linewise: linewise}
+}
+
+// Kill writes text to the unnamed register ("\"") and shifts it into the numbered
+// registers ("1" becomes "2", ..., and the new text becomes "1"), in both Emacs and
+// Vi mode.
+func (r *RegisterSet) Kill(text string, linewise bool) {
+	r.mu.Lock()
+
+	for i := '9'; i > '1'; i-- {
+		if prev, ok := r.registers[string(i-1)]; ok {
+			r.registers[string(i)] = prev
+		}
+	}
+
+	r.registers["1"] = &Register{Text: text, Linewise: linewise}
+	r.registers["\""] = &Register{Text: text, Linewise: linewise}
+
+	r.mu.Unlock()
+}
+
+// Yank returns the text most recently killed, i.e. the unnamed register.
+func (r *RegisterSet) Yank() string {
+	return r.Get("\"").Text
+}
@@ -0,0 +1,72 @@
+package shell
+
+import "testing"
+
+func TestRegisterSetGetSet(t *testing.T) {
+	r := NewRegisterSet()
+
+	if got := r.Get("a"); got.Text != "" || got.Linewise {
+		t.Fatalf("Get on unset register = %+v, want zero value", got)
+	}
+
+	r.Set("a", "hello", false)
+
+	if got := r.Get("a"); got.Text != "hello" || got.Linewise {
+		t.Fatalf("Get(%q) = %+v, want {Text: hello, Linewise: false}", "a", got)
+	}
+
+	r.Set("a", "world", true)
+
+	if got := r.Get("a"); got.Text != "world" || !got.Linewise {
+		t.Fatalf("Set should replace, got %+v", got)
+	}
+}
+
+func TestRegisterSetUppercaseAppends(t *testing.T) {
+	r := NewRegisterSet()
+
+	r.Set("a", "foo", false)
+	r.Set("A", "bar", false)
+
+	got := r.Get("a")
+
+	if got.Text != "foobar" {
+		t.Fatalf("Set(%q, ...) should append to register %q, got Text = %q", "A", "a", got.Text)
+	}
+
+	r.Set("A", "baz", true)
+
+	got = r.Get("a")
+
+	if got.Text != "foobarbaz" {
+		t.Fatalf("repeated uppercase Set should keep appending, got Text = %q", got.Text)
+	}
+
+	if !got.Linewise {
+		t.Fatalf("Linewise should become true once any append sets it, got %+v", got)
+	}
+}
+
+func TestRegisterSetKillAndYank(t *testing.T) {
+	r := NewRegisterSet()
+
+	r.Kill("first", false)
+
+	if got := r.Yank(); got != "first" {
+		t.Fatalf("Yank() = %q, want %q", got, "first")
+	}
+
+	r.Kill("second", false)
+
+	if got := r.Yank(); got != "second" {
+		t.Fatalf("Yank() = %q, want %q", got, "second")
+	}
+
+	if got := r.Get("1"); got.Text != "second" {
+		t.Fatalf(`Get("1") = %+v, want Text "second"`, got)
+	}
+
+	if got := r.Get("2"); got.Text != "first" {
+		t.Fatalf(`Get("2") = %+v, want Text "first" after shifting`, got)
+	}
+}
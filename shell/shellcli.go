@@ -5,8 +5,11 @@ import (
 	"io"
 	"os"
 	"path"
+	"sort"
 	"strconv"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/anti-raid/spintrack/strutils"
 	"github.com/go-andiamo/splitter"
@@ -25,7 +28,30 @@ type ShellCli[T any] struct {
 	Data             *T
 	HistoryPath      string
 
-	line *liner.State
+	// EditMode selects the REPL's keybinding set. Every mode prompts through
+	// lineEditor (see lineeditor.go); the zero value, Default, binds only its base
+	// keys, while Emacs and Vi layer richer bindings on top.
+	EditMode EditMode
+
+	// HistoryStore persists executed commands. If nil, Init() fills in a default
+	// sqliteHistoryStore backed by a database file at HistoryPath + ".db".
+	HistoryStore HistoryStore
+
+	// Output is how the currently-executing command reports results. Exec sets it
+	// for each call based on a "--format=json|yaml|table|text" argument (default
+	// "text"); commands should use it instead of calling fmt.Println directly.
+	Output Output
+
+	// ConfigProvider loads the config file registered via WatchConfig. If nil,
+	// WatchConfig fills in fileConfigProvider (TOML/YAML).
+	ConfigProvider ConfigProvider
+
+	line       *liner.State
+	lineEditor *lineEditor[T]
+	registers  *RegisterSet
+	configPath string
+	configMu   sync.RWMutex
+	config     *ShellConfig
 }
 
 // Returns a help command
@@ -35,6 +61,13 @@ func (s *ShellCli[T]) Help() *Command[T] {
 		Description: "Get help for a command",
 		Args: [][3]string{
 			{"command", "Command to get help for", ""},
+			// One level deep only: help for commands nested further than this
+			// (e.g. "help db migrate up") isn't supported, since Args has no
+			// variadic/catch-all form to describe arbitrary depth.
+			{"subcommand", "Subcommand to get help for", ""},
+		},
+		Schema: &OutputSchema{
+			Fields: []string{"command", "description", "args", "subcommands"},
 		},
 		Completer: func(a *ShellCli[T], line string, args map[string]string) ([]string, error) {
 			cmd, ok := args["command"]
@@ -49,6 +82,23 @@ func (s *ShellCli[T]) Help() *Command[T] {
 
 			cmd = strings.ToLower(cmd)
 
+			if sub, ok := args["subcommand"]; ok && sub != "" {
+				cmdData, ok := a.Commands[cmd]
+				if !ok {
+					return nil, nil
+				}
+
+				sub = strings.ToLower(sub)
+
+				var completions []string
+				for name := range cmdData.Subcommands {
+					if strings.HasPrefix(name, sub) {
+						completions = append(completions, name)
+					}
+				}
+				return completions, nil
+			}
+
 			var completions []string
 
 			for name := range a.Commands {
@@ -67,21 +117,64 @@ func (s *ShellCli[T]) Help() *Command[T] {
 					return fmt.Errorf("unknown command: %s", arg)
 				}
 
-				fmt.Println("Command: ", arg)
-				fmt.Println("Description: ", cmd.Description)
-				fmt.Println("Arguments: ")
+				if sub, ok := args["subcommand"]; ok && sub != "" {
+					subCmd, ok := cmd.Subcommands[sub]
+
+					if !ok {
+						return fmt.Errorf("unknown subcommand: %s %s", arg, sub)
+					}
+
+					arg = arg + " " + sub
+					cmd = subCmd
+				}
+
+				argRows := make([][]string, 0, len(cmd.Args))
+
+				for _, argSpec := range cmd.Args {
+					argRows = append(argRows, []string{argSpec[0], argSpec[1], argSpec[2]})
+				}
+
+				subNames := make([]string, 0, len(cmd.Subcommands))
 
-				for _, cmd := range cmd.Args {
-					fmt.Print("  ", cmd[0], " : ", cmd[1], " (default: ", cmd[2], ")\n")
+				for name := range cmd.Subcommands {
+					subNames = append(subNames, name)
 				}
+
+				sort.Strings(subNames)
+
+				a.Output.Emit(map[string]any{
+					"command":     arg,
+					"description": cmd.Description,
+					"args":        argRows,
+					"subcommands": subNames,
+				})
 			} else {
-				fmt.Println("Commands: ")
+				headers := []string{"Group", "Command", "Description"}
+
+				names := make([]string, 0, len(a.Commands))
+
+				for name := range a.Commands {
+					names = append(names, name)
+				}
 
-				for cmd, desc := range a.Commands {
-					fmt.Print("  ", cmd, ": ", desc.Description, "\n")
+				sort.Strings(names)
+
+				rows := make([][]string, 0, len(names))
+
+				for _, name := range names {
+					cmd := a.Commands[name]
+					group := cmd.Group
+
+					if group == "" {
+						group = "General"
+					}
+
+					rows = append(rows, []string{group, name, cmd.Description})
 				}
 
-				fmt.Println("Use 'help <command>' to get help for a specific command")
+				sort.SliceStable(rows, func(i, j int) bool { return rows[i][0] < rows[j][0] })
+
+				a.Output.Table(headers, rows)
 			}
 
 			return nil
@@ -96,6 +189,19 @@ type Command[T any] struct {
 	Args        [][3]string // Map of argument to the description and default value
 	Run         func(a *ShellCli[T], args map[string]string) error
 	Completer   func(a *ShellCli[T], line string, args map[string]string) ([]string, error)
+
+	// Schema optionally describes this command's output shape, so that JSON/YAML
+	// emitters (see Output) produce stable keys. Commands that only emit tabular
+	// data via Output.Table don't need one.
+	Schema *OutputSchema
+
+	// Subcommands nests further commands under this one (e.g. "db migrate up").
+	// ParseOutCommand descends into these as far as the input tokens allow.
+	Subcommands map[string]*Command[T]
+
+	// Group categorizes this command for Help()'s grouped listing (e.g. "Database").
+	// Commands with an empty Group are listed under "General".
+	Group string
 }
 
 // Init initializes the shell client
@@ -124,12 +230,47 @@ func (a *ShellCli[T]) Init() error {
 
 	a.HistoryPath = path.Join(os.TempDir(), a.HistoryPath)
 
+	a.registers = NewRegisterSet()
+
+	if a.Output == nil {
+		a.Output = NewOutput("text")
+	}
+
+	if a.HistoryStore == nil {
+		store, err := NewSQLiteHistoryStore(a.HistoryPath + ".db")
+
+		if err != nil {
+			return fmt.Errorf("error initializing default history store: %s", err)
+		}
+
+		a.HistoryStore = store
+	}
+
 	return nil
 }
 
-func (a *ShellCli[T]) ParseOutCommand(cmd []string) (*Command[T], error) {
+// Registers returns the kill-ring/register store backing Emacs's Ctrl-Y and Vi's
+// registers, so that commands can inspect or seed them.
+func (a *ShellCli[T]) Registers() *RegisterSet {
+	if a.registers == nil {
+		a.registers = NewRegisterSet()
+	}
+
+	return a.registers
+}
+
+// ParseOutCommand resolves the command tokens name, descending into
+// Command[T].Subcommands as far as possible, and returns the resolved leaf
+// command along with the remaining tokens (its arguments). Descent stops at the
+// first token that looks like an "a=b" argument, or that doesn't name a
+// subcommand of the current leaf.
+//
+// Breaking change: this used to return (*Command[T], error); callers that
+// resolve a command by name should also take the remaining-tokens return
+// value instead of assuming cmd[1:] are a flat command's args.
+func (a *ShellCli[T]) ParseOutCommand(cmd []string) (*Command[T], []string, error) {
 	if len(cmd) == 0 {
-		return nil, nil
+		return nil, nil, nil
 	}
 
 	cmdName := cmd[0]
@@ -141,10 +282,49 @@ func (a *ShellCli[T]) ParseOutCommand(cmd []string) (*Command[T], error) {
 	cmdData, ok := a.Commands[cmdName]
 
 	if !ok {
-		return nil, fmt.Errorf("unknown command: %s", cmd[0])
+		if cfg := a.Config(); cfg != nil {
+			if real, aliased := cfg.Aliases[cmdName]; aliased {
+				cmdData, ok = a.Commands[real]
+			}
+		}
+
+		if !ok {
+			return nil, nil, fmt.Errorf("unknown command: %s", cmd[0])
+		}
 	}
 
-	return cmdData, nil
+	rest := cmd[1:]
+
+	for len(cmdData.Subcommands) > 0 && len(rest) > 0 {
+		next := rest[0]
+
+		if looksLikeArg(next) {
+			break
+		}
+
+		subName := next
+
+		if a.CaseInsensitive {
+			subName = strings.ToLower(subName)
+		}
+
+		sub, ok := cmdData.Subcommands[subName]
+
+		if !ok {
+			break
+		}
+
+		cmdData = sub
+		rest = rest[1:]
+	}
+
+	return cmdData, rest, nil
+}
+
+// looksLikeArg reports whether tok is shaped like an "a=b" command argument
+// rather than a subcommand name.
+func looksLikeArg(tok string) bool {
+	return strings.Contains(tok, "=")
 }
 
 func (a *ShellCli[T]) CreateArgMapFromArgs(cmdData *Command[T], args []string) (map[string]string, error) {
@@ -159,7 +339,7 @@ func (a *ShellCli[T]) CreateArgMapFromArgs(cmdData *Command[T], args []string) (
 
 		if len(fields) == 1 {
 			if len(cmdData.Args) <= i {
-				fmt.Println("WARNING: extra argument: ", fields[0])
+				a.Output.Emit("WARNING: extra argument: " + fields[0])
 				continue
 			}
 
@@ -175,12 +355,20 @@ func (a *ShellCli[T]) CreateArgMapFromArgs(cmdData *Command[T], args []string) (
 		argMap[fields[0]] = fields[1]
 	}
 
+	if cfg := a.Config(); cfg != nil {
+		for name, value := range cfg.DefaultArgs[cmdData.Name] {
+			if _, ok := argMap[name]; !ok {
+				argMap[name] = value
+			}
+		}
+	}
+
 	return argMap, nil
 }
 
 // Exec executes a command
 func (a *ShellCli[T]) Exec(cmd []string) error {
-	cmdData, err := a.ParseOutCommand(cmd)
+	cmdData, rest, err := a.ParseOutCommand(cmd)
 
 	if err != nil {
 		return err
@@ -190,7 +378,13 @@ func (a *ShellCli[T]) Exec(cmd []string) error {
 		return nil
 	}
 
-	args := cmd[1:]
+	args, format := extractFormatFlag(rest)
+
+	output := NewOutput(format)
+	if bo, ok := output.(*basicOutput); ok {
+		bo.schema = cmdData.Schema
+	}
+	a.Output = output
 
 	argMap, err := a.CreateArgMapFromArgs(cmdData, args)
 
@@ -207,6 +401,26 @@ func (a *ShellCli[T]) Exec(cmd []string) error {
 	return nil
 }
 
+// extractFormatFlag pulls a "--format=json|yaml|table|text" token out of args,
+// returning the remaining positional/named args and the selected format (default
+// "text"). It runs before CreateArgMapFromArgs so the flag doesn't shift
+// positional argument indices.
+func extractFormatFlag(args []string) ([]string, string) {
+	format := "text"
+	filtered := make([]string, 0, len(args))
+
+	for _, arg := range args {
+		if rest, ok := strings.CutPrefix(arg, "--format="); ok {
+			format = rest
+			continue
+		}
+
+		filtered = append(filtered, arg)
+	}
+
+	return filtered, format
+}
+
 func (a *ShellCli[T]) RunString(command string) (bool, error) {
 	command = strings.TrimSpace(command)
 
@@ -277,6 +491,10 @@ func (a *ShellCli[T]) Run() {
 		os.Exit(1)
 	}
 
+	// a.line is kept around for its in-memory history list (AppendHistory, used by
+	// RunString) and so OnInterrupt can still close a liner.State on Ctrl-C; actual
+	// prompting goes through lineEditor below, which is what drives Ctrl-R against
+	// HistoryStore regardless of EditMode.
 	a.line = liner.NewLiner()
 	defer a.line.Close()
 	OnInterrupt(func() {
@@ -284,15 +502,16 @@ func (a *ShellCli[T]) Run() {
 	})
 
 	a.line.SetCtrlCAborts(true)
-	a.line.SetTabCompletionStyle(liner.TabPrints)
 
-	a.line.SetCompleter(a.CompletionHandler) // Set the completion handler
 	a.loadHistory()
 
 	defer a.saveHistory()
 
+	a.lineEditor = newLineEditor(a)
+	defer a.lineEditor.Close()
+
 	for {
-		cmd, err := a.line.Prompt(a.Prompter(a))
+		cmd, err := a.prompt()
 		if err != nil {
 			if err != io.EOF {
 				fmt.Printf("Prompt Error: %v\n", err)
@@ -300,10 +519,13 @@ func (a *ShellCli[T]) Run() {
 			return
 		}
 
+		start := time.Now()
 		cancel, err := a.ExecuteCommands(cmd)
 
+		a.recordHistory(cmd, start, err)
+
 		if err != nil {
-			fmt.Println("Error: ", err)
+			a.Output.Error(err)
 		}
 
 		if cancel {
@@ -312,6 +534,13 @@ func (a *ShellCli[T]) Run() {
 	}
 }
 
+// prompt reads one line of input via lineEditor, which handles base editing
+// (history, Tab completion, cursor keys) plus Ctrl-R and, when EditMode is Emacs
+// or Vi, that mode's extra bindings.
+func (a *ShellCli[T]) prompt() (string, error) {
+	return a.lineEditor.Prompt(a.Prompter(a))
+}
+
 // CompletionHandler is the completion handler for the shell client
 //
 // This may be useful for bash completion scripts etc.
@@ -351,7 +580,7 @@ func (a *ShellCli[T]) CompletionHandler(line string) (c []string) {
 		}
 
 		// Try calling the command's completer
-		cmdData, err := a.ParseOutCommand(tokens)
+		cmdData, rest, err := a.ParseOutCommand(tokens)
 
 		if err != nil {
 			if a.DebugCompletions {
@@ -370,9 +599,27 @@ func (a *ShellCli[T]) CompletionHandler(line string) (c []string) {
 			return
 		}
 
+		// If we're still inside a subcommand tree, complete subcommand names
+		// before falling back to the leaf's own completer
+		if len(cmdData.Subcommands) > 0 && len(rest) <= 1 {
+			prefix := ""
+			if len(rest) == 1 {
+				prefix = rest[0]
+			}
+
+			if !looksLikeArg(prefix) {
+				for name := range cmdData.Subcommands {
+					if strings.HasPrefix(name, strings.ToLower(prefix)) {
+						c = append(c, name+" ")
+					}
+				}
+				return
+			}
+		}
+
 		// If the command has a completer, run it
 		if cmdData.Completer != nil {
-			args := tokens[1:]
+			args := rest
 
 			argMap, err := a.CreateArgMapFromArgs(cmdData, args)
 
@@ -476,21 +723,29 @@ func (s *ShellCli[T]) GetCompletion() *Command[T] {
 
 			completions := a.CompletionHandler(line)
 
+			// The global --format flag (json/yaml) takes priority over the
+			// command's own "format" arg, which only controls how plain text
+			// output is shaped.
+			if outFormat := formatOf(a.Output); outFormat == "json" || outFormat == "yaml" {
+				a.Output.Emit(completions)
+				return nil
+			}
+
 			switch format {
 			case "printNewlineArray":
 				for i, completion := range completions {
-					fmt.Println(strconv.Itoa(i) + ") " + completion)
+					a.Output.Emit(strconv.Itoa(i) + ") " + completion)
 				}
 			case "printArray":
-				fmt.Println(completions)
+				a.Output.Emit(completions)
 			case "strJoinArray_spaceSep":
-				fmt.Println(strings.Join(completions, " "))
+				a.Output.Emit(strings.Join(completions, " "))
 			case "strJoinArray_newlineSep":
-				fmt.Println(strings.Join(completions, "\n"))
+				a.Output.Emit(strings.Join(completions, "\n"))
 			case "strJoinArray_commaSep":
-				fmt.Println(strings.Join(completions, ","))
+				a.Output.Emit(strings.Join(completions, ","))
 			case "strJoinArray_commaSpaceSep":
-				fmt.Println(strings.Join(completions, ", "))
+				a.Output.Emit(strings.Join(completions, ", "))
 			default:
 				return fmt.Errorf("unknown format: %s", format)
 			}
@@ -502,21 +757,56 @@ func (s *ShellCli[T]) GetCompletion() *Command[T] {
 	return cmd
 }
 
+// loadHistory seeds liner's in-memory history list from the HistoryStore, most
+// recent last. Up/Down recall reads lineEditor's own copy, not this list.
 func (a *ShellCli[T]) loadHistory() {
-	if f, err := os.Open(a.HistoryPath); err == nil {
-		a.line.ReadHistory(f)
-		f.Close()
+	entries, err := a.HistoryStore.Search("", HistoryScopeGlobal)
+
+	if err != nil {
+		fmt.Printf("Error loading history: %v\n", err)
+		return
+	}
+
+	for i := len(entries) - 1; i >= 0; i-- {
+		a.line.AppendHistory(entries[i].Command)
 	}
 }
 
+// saveHistory closes the HistoryStore. Individual commands are already durable by
+// the time this runs, since recordHistory records them as they execute.
 func (a *ShellCli[T]) saveHistory() {
-	if f, err := os.Create(a.HistoryPath); err != nil {
-		fmt.Printf("Error creating history file: %v\n", err)
-	} else {
-		if _, err = a.line.WriteHistory(f); err != nil {
-			fmt.Printf("Error writing history file: %v\n", err)
-		}
-		f.Close()
+	if err := a.HistoryStore.Close(); err != nil {
+		fmt.Printf("Error closing history store: %v\n", err)
+	}
+}
+
+// recordHistory persists one executed command to the HistoryStore, capturing its
+// start time, duration, working directory and whether it failed.
+func (a *ShellCli[T]) recordHistory(cmd string, start time.Time, execErr error) {
+	if strings.TrimSpace(cmd) == "" {
+		return
+	}
+
+	cwd, err := os.Getwd()
+
+	if err != nil {
+		cwd = ""
+	}
+
+	err = a.HistoryStore.Record(HistoryEntry{
+		Command:    cmd,
+		Timestamp:  start.Unix(),
+		Cwd:        cwd,
+		Failed:     execErr != nil,
+		DurationMs: time.Since(start).Milliseconds(),
+	})
+
+	if err != nil {
+		fmt.Printf("Error recording history: %v\n", err)
+	}
+
+	if a.lineEditor != nil {
+		a.lineEditor.appendHistory(cmd)
 	}
 }
 